@@ -0,0 +1,133 @@
+package sqlxentrypoint
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// BalancerPolicy selects which of the given candidate slaves should serve
+// the next read. candidates holds stable indices into the DB's full slave
+// list (db.pdbs[1:]) — not positions in a filtered or reordered slice — so
+// implementations that key state per slave (weights, in-flight counts) can
+// use a candidate value directly as an array index regardless of which
+// slaves happen to be healthy on a given call. candidates is never empty.
+// Implementations must be safe for concurrent use.
+type BalancerPolicy interface {
+	Pick(candidates []int) int
+}
+
+// releaser is implemented by balancer policies that need to know when a
+// query dispatched to a previously picked slave has finished, so they can
+// keep per-slave accounting (such as an in-flight count) accurate.
+type releaser interface {
+	Release(i int)
+}
+
+// RoundRobin is the default BalancerPolicy: it cycles through slaves in
+// order, giving each an equal share of reads. It is the zero-value-usable
+// equivalent of nap's original slave() method.
+type RoundRobin struct {
+	count uint64
+}
+
+// Pick returns the next candidate in round-robin order.
+func (r *RoundRobin) Pick(candidates []int) int {
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	i := int(atomic.AddUint64(&r.count, 1) % uint64(len(candidates)))
+	return candidates[i]
+}
+
+// WeightedRoundRobin picks slaves in proportion to their configured
+// weights, so a beefier replica can be given a larger share of reads, using
+// the smooth weighted round-robin algorithm (as used by nginx upstreams).
+// Weights are indexed by the stable slave index, so a slave keeps its
+// configured weight whether or not it's a candidate on any given call.
+type WeightedRoundRobin struct {
+	weights []int // indexed by stable slave index
+
+	mu      sync.Mutex
+	current []int // same indexing as weights
+}
+
+// NewWeightedRoundRobin builds a WeightedRoundRobin from one weight per
+// slave. Non-positive weights are treated as 1.
+func NewWeightedRoundRobin(weights []int) *WeightedRoundRobin {
+	w := make([]int, len(weights))
+	for i, v := range weights {
+		if v <= 0 {
+			v = 1
+		}
+		w[i] = v
+	}
+	return &WeightedRoundRobin{weights: w, current: make([]int, len(w))}
+}
+
+// Pick adds each candidate's weight to its current weight, returns the
+// candidate with the highest current weight, and deducts the total weight
+// of this round from it, spreading picks smoothly instead of bursting on
+// the heaviest slave.
+// Slaves excluded from candidates (e.g. unhealthy ones) simply don't accrue
+// weight for the round, rather than losing their configured share.
+func (w *WeightedRoundRobin) Pick(candidates []int) int {
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	total := 0
+	for _, idx := range candidates {
+		weight := w.weights[idx]
+		w.current[idx] += weight
+		total += weight
+	}
+
+	best := candidates[0]
+	for _, idx := range candidates[1:] {
+		if w.current[idx] > w.current[best] {
+			best = idx
+		}
+	}
+	w.current[best] -= total
+	return best
+}
+
+// LeastInFlight picks the slave with the fewest outstanding queries. Counts
+// are indexed by the stable slave index, incremented here at pick time, and
+// decremented via Release once the dispatched query completes (DB wires
+// this up automatically for its Context methods, Stmt and NamedStmt). A
+// slave handed out by DB.Slave is released as soon as it is returned, so
+// queries run on it are not counted.
+type LeastInFlight struct {
+	inFlight []int64 // indexed by stable slave index, fixed size
+}
+
+// NewLeastInFlight builds a LeastInFlight tracker for n slaves.
+func NewLeastInFlight(n int) *LeastInFlight {
+	return &LeastInFlight{inFlight: make([]int64, n)}
+}
+
+// Pick returns the candidate with the lowest in-flight count, breaking ties
+// in favor of the first candidate, and marks it as having one more query in
+// flight.
+func (l *LeastInFlight) Pick(candidates []int) int {
+	best := candidates[0]
+	for _, idx := range candidates[1:] {
+		if atomic.LoadInt64(&l.inFlight[idx]) < atomic.LoadInt64(&l.inFlight[best]) {
+			best = idx
+		}
+	}
+	atomic.AddInt64(&l.inFlight[best], 1)
+	return best
+}
+
+// Release decrements the in-flight count for slave i.
+func (l *LeastInFlight) Release(i int) {
+	if i < 0 || i >= len(l.inFlight) {
+		return
+	}
+	atomic.AddInt64(&l.inFlight[i], -1)
+}
@@ -0,0 +1,86 @@
+package sqlxentrypoint
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRoundRobinCyclesEvenly(t *testing.T) {
+	r := new(RoundRobin)
+	candidates := []int{0, 1, 2}
+	counts := make(map[int]int)
+	for i := 0; i < 300; i++ {
+		counts[r.Pick(candidates)]++
+	}
+	for _, idx := range candidates {
+		if counts[idx] != 100 {
+			t.Errorf("index %d picked %d times, want 100", idx, counts[idx])
+		}
+	}
+}
+
+func TestRoundRobinSingleCandidate(t *testing.T) {
+	r := new(RoundRobin)
+	if got := r.Pick([]int{2}); got != 2 {
+		t.Errorf("Pick() = %d, want 2", got)
+	}
+}
+
+func TestWeightedRoundRobinRespectsWeights(t *testing.T) {
+	w := NewWeightedRoundRobin([]int{1, 3})
+	candidates := []int{0, 1}
+	counts := make(map[int]int)
+	for i := 0; i < 400; i++ {
+		counts[w.Pick(candidates)]++
+	}
+	if counts[0] != 100 || counts[1] != 300 {
+		t.Errorf("got counts %v, want {0:100, 1:300}", counts)
+	}
+}
+
+// TestWeightedRoundRobinSurvivesFiltering is a regression test: weights are
+// keyed by stable slave index, so excluding a slave from candidates (as
+// healthyIndices does for an unhealthy one) must not shift another slave's
+// configured weight.
+func TestWeightedRoundRobinSurvivesFiltering(t *testing.T) {
+	w := NewWeightedRoundRobin([]int{1, 3, 1})
+	counts := make(map[int]int)
+	for i := 0; i < 400; i++ {
+		// Index 2 is never a candidate, as if permanently unhealthy.
+		counts[w.Pick([]int{0, 1})]++
+	}
+	if counts[0] != 100 || counts[1] != 300 {
+		t.Errorf("got counts %v, want {0:100, 1:300}", counts)
+	}
+}
+
+func TestLeastInFlightPicksFewestInFlight(t *testing.T) {
+	l := NewLeastInFlight(3)
+	l.Pick([]int{0, 1, 2}) // index 0 now has 1 in flight
+	l.Pick([]int{0, 1, 2}) // index 1 now has 1 in flight
+
+	got := l.Pick([]int{0, 1, 2})
+	if got != 2 {
+		t.Errorf("Pick() = %d, want 2 (the only slave with nothing in flight)", got)
+	}
+
+	l.Release(2)
+	got = l.Pick([]int{0, 1, 2})
+	if got != 2 {
+		t.Errorf("after Release, Pick() = %d, want 2 again", got)
+	}
+}
+
+func TestLeastInFlightConcurrent(t *testing.T) {
+	l := NewLeastInFlight(4)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			idx := l.Pick([]int{0, 1, 2, 3})
+			l.Release(idx)
+		}()
+	}
+	wg.Wait()
+}
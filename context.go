@@ -0,0 +1,93 @@
+package sqlxentrypoint
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ctxKey is an unexported type for context keys defined in this package,
+// avoiding collisions with keys defined in other packages.
+type ctxKey int
+
+const forceMasterKey ctxKey = 0
+
+// ForceMaster returns a copy of ctx that pins subsequent read queries made
+// through DB's *Context methods to the master, for the remainder of the
+// request. This is useful for read-your-writes consistency, e.g. reading
+// back a row immediately after inserting it, where a slave might not have
+// replicated the write yet.
+func ForceMaster(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceMasterKey, true)
+}
+
+// forcedMaster reports whether ctx was produced by ForceMaster.
+func forcedMaster(ctx context.Context) bool {
+	v, _ := ctx.Value(forceMasterKey).(bool)
+	return v
+}
+
+// writeQuery matches the leading keyword of statements that mutate data and
+// must therefore be routed to the master.
+var writeQuery = regexp.MustCompile(`(?i)^\s*(insert|update|delete|replace)\b`)
+
+// dispatchRead runs fn against the master if ctx was forced or query is a
+// write, or otherwise against a healthy slave, transparently retrying on
+// another slave (and optionally falling back to the master) if fn fails
+// with a connectivity error. See withFailover.
+func (db *DB) dispatchRead(ctx context.Context, query string, fn func(conn *sqlx.DB) error) error {
+	if forcedMaster(ctx) || writeQuery.MatchString(query) {
+		return fn(db.Master())
+	}
+	return db.withFailover(fn)
+}
+
+// QueryxContext dispatches query to the master or a slave depending on its
+// kind and runs it, returning *sqlx.Rows like sqlx.DB.QueryxContext.
+func (db *DB) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	var rows *sqlx.Rows
+	err := db.dispatchRead(ctx, query, func(conn *sqlx.DB) (err error) {
+		rows, err = conn.QueryxContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// GetContext dispatches query to the master or a slave depending on its
+// kind and scans the single resulting row into dest.
+func (db *DB) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return db.dispatchRead(ctx, query, func(conn *sqlx.DB) error {
+		return conn.GetContext(ctx, dest, query, args...)
+	})
+}
+
+// SelectContext dispatches query to the master or a slave depending on its
+// kind and scans the resulting rows into dest.
+func (db *DB) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return db.dispatchRead(ctx, query, func(conn *sqlx.DB) error {
+		return conn.SelectContext(ctx, dest, query, args...)
+	})
+}
+
+// ExecContext always runs query against the master, the same way BeginTxx
+// does: Exec is used for arbitrary statements (DDL, CALL, ...) far beyond
+// the INSERT/UPDATE/DELETE/REPLACE writeQuery recognizes, so guessing from
+// the query text isn't safe here the way it is for the read-oriented
+// Context methods above.
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.Master().ExecContext(ctx, query, args...)
+}
+
+// NamedExecContext always runs query against the master, for the same
+// reason ExecContext does.
+func (db *DB) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	return db.Master().NamedExecContext(ctx, query, arg)
+}
+
+// BeginTxx always starts the transaction on the master, since writes inside
+// a transaction must be consistent with any reads made within it.
+func (db *DB) BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	return db.Master().BeginTxx(ctx, opts)
+}
@@ -0,0 +1,81 @@
+package sqlxentrypoint
+
+import (
+	"context"
+	"testing"
+)
+
+func openFakeDB(t *testing.T, names ...string) *DB {
+	t.Helper()
+	for _, n := range names {
+		newFakeDB(t, n)
+	}
+	dsn := names[0]
+	for _, n := range names[1:] {
+		dsn += ";" + n
+	}
+	db, err := Open("napfake", dsn)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestGetContextGoesToSlave(t *testing.T) {
+	db := openFakeDB(t, "master", "slave0")
+
+	var dest row
+	if err := db.GetContext(context.Background(), &dest, "SELECT * FROM t"); err != nil {
+		t.Fatalf("GetContext() error = %v", err)
+	}
+	if dest.Source != "slave0" {
+		t.Errorf("GetContext() served by %q, want slave0", dest.Source)
+	}
+}
+
+func TestGetContextWithForceMasterGoesToMaster(t *testing.T) {
+	db := openFakeDB(t, "master", "slave0")
+
+	ctx := ForceMaster(context.Background())
+	var dest row
+	if err := db.GetContext(ctx, &dest, "SELECT * FROM t"); err != nil {
+		t.Fatalf("GetContext() error = %v", err)
+	}
+	if dest.Source != "master" {
+		t.Errorf("GetContext() with ForceMaster served by %q, want master", dest.Source)
+	}
+}
+
+func TestGetContextOfWriteQueryGoesToMaster(t *testing.T) {
+	db := openFakeDB(t, "master", "slave0")
+
+	var dest row
+	err := db.GetContext(context.Background(), &dest, "insert into t values (1)")
+	if err != nil {
+		t.Fatalf("GetContext() error = %v", err)
+	}
+	if dest.Source != "master" {
+		t.Errorf("GetContext() of an insert served by %q, want master", dest.Source)
+	}
+}
+
+func TestExecContextAlwaysGoesToMaster(t *testing.T) {
+	master := newFakeDB(t, "master")
+	newFakeDB(t, "slave0")
+	db, err := Open("napfake", "master;slave0")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+
+	// A DDL statement the writeQuery regex used by the read-oriented Context
+	// methods would not recognize as a write, but which must still never
+	// land on a slave.
+	if _, err := db.ExecContext(context.Background(), "CREATE TABLE t (id int)"); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+	if master.execCount() != 1 {
+		t.Errorf("master.execCount() = %d, want 1", master.execCount())
+	}
+}
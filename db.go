@@ -1,27 +1,61 @@
 package sqlxentrypoint
 
 import (
-	"github.com/jmoiron/sqlx"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
 )
 
+// Options configures a DB beyond the bare driver name and DSN list.
+type Options struct {
+	// Balancer picks which slave serves each read. If nil, Open derives one
+	// from the per-DSN weights in dataSourceNames (see Open), falling back
+	// to plain round-robin when no weights are given.
+	Balancer BalancerPolicy
+}
+
 // DB is a entry point for logical database with multiple underlying physical databases
 // forming a single master multiple slaves topology.
 type DB struct {
-	pdbs  []*sqlx.DB // Physical databases
-	count uint64     // Monotonically incrementing counter on each query
+	pdbs []*sqlx.DB // Physical databases, pdbs[0] is the master
+
+	balMu    sync.RWMutex
+	balancer BalancerPolicy
+
+	health *healthState
+	stats  *dispatchStats
+
+	// topology is bumped whenever the set of physical databases behind db
+	// changes, so that long-lived derived state (such as a Stmt's prepared
+	// statements) knows to refresh itself. Nothing bumps it yet since pdbs
+	// is fixed at Open time, but Stmt already checks it in preparation for
+	// dynamic topology changes landing later.
+	topology uint64
 }
 
 // Open concurrently opens each underlying physical db.
 // dataSourceNames must be a semi-comma separated list of DSNs with the first
-// one being used as the master and the rest as slaves.
-func Open(driverName, dataSourceNames string) (*DB, error) {
+// one being used as the master and the rest as slaves. Any DSN, master or
+// slave, may carry a "|w=<weight>" suffix (e.g. "dsn1|w=1;dsn2|w=3") to give
+// that replica a larger or smaller share of reads; the suffix is stripped
+// before the DSN is handed to the driver. opts is optional; pass it to
+// override the derived balancer.
+func Open(driverName, dataSourceNames string, opts ...Options) (*DB, error) {
 	conns := strings.Split(dataSourceNames, ";")
-	db := &DB{pdbs: make([]*sqlx.DB, len(conns))}
+	dsns := make([]string, len(conns))
+	weights := make([]int, len(conns))
+	for i, conn := range conns {
+		dsns[i], weights[i] = splitWeight(conn)
+	}
+
+	db := &DB{pdbs: make([]*sqlx.DB, len(dsns))}
 
 	err := scatter(len(db.pdbs), func(i int) (err error) {
-		db.pdbs[i], err = sqlx.Open(driverName, conns[i])
+		db.pdbs[i], err = sqlx.Open(driverName, dsns[i])
 		return err
 	})
 
@@ -29,11 +63,55 @@ func Open(driverName, dataSourceNames string) (*DB, error) {
 		return nil, err
 	}
 
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	if o.Balancer != nil {
+		db.balancer = o.Balancer
+	} else if slaveWeights := weights[1:]; hasCustomWeight(slaveWeights) {
+		db.balancer = NewWeightedRoundRobin(slaveWeights)
+	} else {
+		db.balancer = new(RoundRobin)
+	}
+
+	db.health = newHealthState(len(db.pdbs) - 1)
+	db.startHealthCheck(defaultHealthCheckInterval)
+	db.stats = newDispatchStats(len(db.pdbs) - 1)
+
 	return db, nil
 }
 
-// Close closes all physical databases concurrently, releasing any open resources.
+// splitWeight splits a "dsn|w=N" DSN into its bare dsn and weight, defaulting
+// to a weight of 1 when no "|w=" suffix is present.
+func splitWeight(conn string) (dsn string, weight int) {
+	dsn, suffix, ok := strings.Cut(conn, "|w=")
+	if !ok {
+		return conn, 1
+	}
+	w, err := strconv.Atoi(suffix)
+	if err != nil || w <= 0 {
+		return dsn, 1
+	}
+	return dsn, w
+}
+
+// hasCustomWeight reports whether any weight in weights differs from the
+// default of 1, i.e. whether a WeightedRoundRobin is actually warranted.
+func hasCustomWeight(weights []int) bool {
+	for _, w := range weights {
+		if w != 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// Close stops the background health checker and closes all physical
+// databases concurrently, releasing any open resources.
 func (db *DB) Close() error {
+	db.startHealthCheck(0)
 	return scatter(len(db.pdbs), func(i int) error {
 		return db.pdbs[i].Close()
 	})
@@ -70,9 +148,46 @@ func (db *DB) SetMaxOpenConns(n int) {
 	}
 }
 
-// Slave returns one of the physical databases which is a slave
+// SetConnMaxLifetime sets the maximum amount of time a connection to each
+// physical database may be reused. Expired connections may be closed
+// lazily before reuse. If d <= 0, connections are not closed due to a
+// connection's age.
+func (db *DB) SetConnMaxLifetime(d time.Duration) {
+	for i := range db.pdbs {
+		db.pdbs[i].SetConnMaxLifetime(d)
+	}
+}
+
+// SetConnMaxIdleTime sets the maximum amount of time a connection to each
+// physical database may be idle before being closed. Expired connections
+// may be closed lazily before reuse. If d <= 0, connections are not closed
+// due to a connection's idle time.
+func (db *DB) SetConnMaxIdleTime(d time.Duration) {
+	for i := range db.pdbs {
+		db.pdbs[i].SetConnMaxIdleTime(d)
+	}
+}
+
+// SetBalancer swaps the BalancerPolicy used to pick a slave for each read.
+// Safe to call concurrently with in-flight queries.
+func (db *DB) SetBalancer(p BalancerPolicy) {
+	db.balMu.Lock()
+	db.balancer = p
+	db.balMu.Unlock()
+}
+
+func (db *DB) getBalancer() BalancerPolicy {
+	db.balMu.RLock()
+	defer db.balMu.RUnlock()
+	return db.balancer
+}
+
+// Slave returns one of the physical databases which is a slave, as picked by
+// the current BalancerPolicy.
 func (db *DB) Slave() *sqlx.DB {
-	return db.pdbs[db.slave(len(db.pdbs))]
+	conn, release := db.pickSlave()
+	release()
+	return conn
 }
 
 // Master returns the master physical database
@@ -80,9 +195,41 @@ func (db *DB) Master() *sqlx.DB {
 	return db.pdbs[0]
 }
 
-func (db *DB) slave(n int) int {
-	if n <= 1 {
-		return 0
+// topologyEpoch returns the current topology epoch, bumped whenever the set
+// of physical databases behind db changes.
+func (db *DB) topologyEpoch() uint64 {
+	return atomic.LoadUint64(&db.topology)
+}
+
+// pickSlave asks the BalancerPolicy to choose a healthy slave and returns it
+// along with a release func that must be called once the caller is done
+// with it, so that in-flight-aware policies like LeastInFlight stay
+// accurate. If there are no slaves, the master is returned and release is a
+// no-op.
+func (db *DB) pickSlave() (conn *sqlx.DB, release func()) {
+	idx, release, ok := db.pickHealthyIndex()
+	if !ok {
+		return db.pdbs[0], func() {}
+	}
+	return db.pdbs[1+idx], release
+}
+
+// pickHealthyIndex asks the BalancerPolicy to choose a healthy slave and
+// returns its stable index into db.pdbs[1:], which pickSlave maps to the
+// slave's *sqlx.DB. The returned release func must be called once the
+// caller is done with the pick, so in-flight-aware policies like
+// LeastInFlight stay accurate. ok is false if there are no slaves at all.
+func (db *DB) pickHealthyIndex() (idx int, release func(), ok bool) {
+	candidates := db.healthyIndices()
+	if len(candidates) == 0 {
+		return 0, func() {}, false
+	}
+
+	balancer := db.getBalancer()
+	idx = balancer.Pick(candidates)
+	db.stats.incDispatched(idx)
+	if r, ok := balancer.(releaser); ok {
+		return idx, func() { r.Release(idx) }, true
 	}
-	return int(1 + (atomic.AddUint64(&db.count, 1) % uint64(n-1)))
+	return idx, func() {}, true
 }
@@ -0,0 +1,185 @@
+package sqlxentrypoint
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+// fakeDriver backs the DB-level tests (context, health/failover, stmt) with
+// an in-memory database/sql/driver implementation, so those tests can run
+// without a real database. Each physical db is named by its DSN and looked
+// up in fakeRegistry; Open's dataSourceNames should therefore be a
+// ";"-separated list of names registered via newFakeDB.
+type fakeDriver struct{}
+
+func init() {
+	sql.Register("napfake", &fakeDriver{})
+}
+
+var (
+	fakeRegistryMu sync.Mutex
+	fakeRegistry   = map[string]*fakeDB{}
+)
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeRegistryMu.Lock()
+	db := fakeRegistry[name]
+	fakeRegistryMu.Unlock()
+	if db == nil {
+		return nil, fmt.Errorf("fakedriver: unknown dsn %q", name)
+	}
+	return &fakeConn{db: db}, nil
+}
+
+// fakeDB is one physical database: it records every query/exec it serves
+// and can be made to fail its next call, or every Ping, on demand.
+type fakeDB struct {
+	name string
+
+	mu      sync.Mutex
+	pingErr error
+	nextErr error
+	queries []string
+	execs   []string
+}
+
+// newFakeDB registers a fakeDB under name for the lifetime of t, so Open can
+// find it by DSN.
+func newFakeDB(t *testing.T, name string) *fakeDB {
+	t.Helper()
+	db := &fakeDB{name: name}
+	fakeRegistryMu.Lock()
+	fakeRegistry[name] = db
+	fakeRegistryMu.Unlock()
+	t.Cleanup(func() {
+		fakeRegistryMu.Lock()
+		delete(fakeRegistry, name)
+		fakeRegistryMu.Unlock()
+	})
+	return db
+}
+
+func (db *fakeDB) setPingErr(err error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.pingErr = err
+}
+
+// failNext makes this db's next Exec or Query call (but not ones after it)
+// return err, to simulate a single transient connectivity failure.
+func (db *fakeDB) failNext(err error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.nextErr = err
+}
+
+func (db *fakeDB) takeErr() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	err := db.nextErr
+	db.nextErr = nil
+	return err
+}
+
+func (db *fakeDB) recordQuery(query string) {
+	db.mu.Lock()
+	db.queries = append(db.queries, query)
+	db.mu.Unlock()
+}
+
+func (db *fakeDB) recordExec(query string) {
+	db.mu.Lock()
+	db.execs = append(db.execs, query)
+	db.mu.Unlock()
+}
+
+func (db *fakeDB) queryCount() int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return len(db.queries)
+}
+
+func (db *fakeDB) execCount() int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return len(db.execs)
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c *fakeConn) Ping(ctx context.Context) error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	return c.db.pingErr
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if err := s.conn.db.takeErr(); err != nil {
+		return nil, err
+	}
+	s.conn.db.recordExec(s.query)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if err := s.conn.db.takeErr(); err != nil {
+		return nil, err
+	}
+	s.conn.db.recordQuery(s.query)
+	return &fakeRows{source: s.conn.db.name}, nil
+}
+
+// fakeRows yields a single row with columns (id, source), where source is
+// the name of the physical db that served the query, so tests can assert on
+// which physical db a read was dispatched to.
+type fakeRows struct {
+	source string
+	done   bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"id", "source"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	dest[1] = r.source
+	return nil
+}
+
+// row is the scan target used by tests that Get/Select through fakeRows.
+type row struct {
+	ID     int64  `db:"id"`
+	Source string `db:"source"`
+}
@@ -0,0 +1,237 @@
+package sqlxentrypoint
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// defaultHealthCheckInterval is how often Open's background health checker
+// pings each slave when SetHealthCheckInterval hasn't been called.
+const defaultHealthCheckInterval = 10 * time.Second
+
+// healthState tracks which slaves are currently considered reachable and
+// drives the background checker started by Open.
+type healthState struct {
+	mu      sync.RWMutex
+	healthy []bool
+
+	failoverToMaster int32 // atomic bool
+
+	tickerMu sync.Mutex
+	stop     chan struct{}
+}
+
+func newHealthState(n int) *healthState {
+	healthy := make([]bool, n)
+	for i := range healthy {
+		healthy[i] = true
+	}
+	return &healthState{healthy: healthy}
+}
+
+// startHealthCheck launches the background checker at the given interval,
+// stopping any previously running one. An interval <= 0 disables periodic
+// checking without altering the last known health of each slave.
+func (db *DB) startHealthCheck(interval time.Duration) {
+	db.health.tickerMu.Lock()
+	defer db.health.tickerMu.Unlock()
+
+	if db.health.stop != nil {
+		close(db.health.stop)
+		db.health.stop = nil
+	}
+	if interval <= 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	db.health.stop = stop
+	go db.healthCheckLoop(interval, stop)
+}
+
+func (db *DB) healthCheckLoop(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			db.checkSlaveHealth()
+		}
+	}
+}
+
+func (db *DB) checkSlaveHealth() {
+	slaves := db.pdbs[1:]
+	_ = scatter(len(slaves), func(i int) error {
+		err := slaves[i].Ping()
+		db.setHealthy(i, err == nil)
+		return nil
+	})
+}
+
+// SetHealthCheckInterval changes how often the background health checker
+// pings each slave. Pass d <= 0 to stop periodic checking; Healthy() will
+// then keep returning whatever it last observed.
+func (db *DB) SetHealthCheckInterval(d time.Duration) {
+	db.startHealthCheck(d)
+}
+
+// SetFailoverToMaster controls what happens once every slave has been tried
+// and failed: when true, reads fall back to the master; when false (the
+// default) the last slave error is returned to the caller.
+func (db *DB) SetFailoverToMaster(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&db.health.failoverToMaster, v)
+}
+
+func (db *DB) failoverToMasterEnabled() bool {
+	return atomic.LoadInt32(&db.health.failoverToMaster) != 0
+}
+
+// Healthy reports, for each slave in the order passed to Open, whether the
+// last health check (or failed query) found it reachable.
+func (db *DB) Healthy() []bool {
+	db.health.mu.RLock()
+	defer db.health.mu.RUnlock()
+	out := make([]bool, len(db.health.healthy))
+	copy(out, db.health.healthy)
+	return out
+}
+
+func (db *DB) setHealthy(i int, healthy bool) {
+	db.health.mu.Lock()
+	if i >= 0 && i < len(db.health.healthy) {
+		db.health.healthy[i] = healthy
+	}
+	db.health.mu.Unlock()
+}
+
+func (db *DB) markUnhealthy(i int) {
+	db.setHealthy(i, false)
+}
+
+// healthyIndices returns the stable indices (into db.pdbs[1:]) of the
+// currently-healthy slaves, preserving order. If none are healthy it
+// returns every slave index, so a total replica outage degrades to the old
+// blind-rotation behavior rather than refusing all reads outright.
+func (db *DB) healthyIndices() []int {
+	n := len(db.pdbs) - 1
+
+	var indices, skipped []int
+	db.health.mu.RLock()
+	for i := 0; i < n; i++ {
+		if i >= len(db.health.healthy) || db.health.healthy[i] {
+			indices = append(indices, i)
+		} else {
+			skipped = append(skipped, i)
+		}
+	}
+	db.health.mu.RUnlock()
+
+	if len(indices) == 0 {
+		indices = make([]int, n)
+		for i := range indices {
+			indices[i] = i
+		}
+		return indices
+	}
+	for _, i := range skipped {
+		db.stats.incSkipped(i)
+	}
+	return indices
+}
+
+// isConnErr reports whether err looks like a failure of the connection
+// itself, as opposed to e.g. a constraint violation, and therefore warrants
+// marking the slave unhealthy and retrying elsewhere.
+func isConnErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// withFailover runs fn against a healthy slave. If fn fails with a
+// connectivity-looking error, that slave is marked unhealthy and fn is
+// retried against another healthy one; once every slave has been tried,
+// fn runs against the master if SetFailoverToMaster(true) was set,
+// otherwise the last slave error is returned.
+func (db *DB) withFailover(fn func(conn *sqlx.DB) error) error {
+	return db.withFailoverIndex(
+		func(idx int) error { return fn(db.pdbs[1+idx]) },
+		func() error { return fn(db.Master()) },
+	)
+}
+
+// withFailoverIndex is withFailover generalized over what "a slave" means:
+// onSlave is called with the stable index of a healthy slave rather than a
+// *sqlx.DB, so callers that hold per-slave state other than a connection
+// (e.g. Stmt, which holds one *sqlx.Stmt per slave) get the same automatic
+// failover and health tracking as DB's own Context methods. onMaster is
+// called once every slave has been tried and failed if
+// SetFailoverToMaster(true) was set (otherwise the last slave error is
+// returned), or immediately if there are no slaves at all.
+func (db *DB) withFailoverIndex(onSlave func(idx int) error, onMaster func() error) error {
+	tried := make(map[int]bool)
+	var lastErr error
+
+	for {
+		candidates := excludeTried(db.healthyIndices(), tried)
+		if len(candidates) == 0 {
+			break
+		}
+
+		balancer := db.getBalancer()
+		idx := balancer.Pick(candidates)
+		db.stats.incDispatched(idx)
+
+		err := onSlave(idx)
+		if r, ok := balancer.(releaser); ok {
+			r.Release(idx)
+		}
+		if err == nil || !isConnErr(err) {
+			return err
+		}
+
+		db.markUnhealthy(idx)
+		tried[idx] = true
+		lastErr = err
+	}
+
+	if lastErr == nil || db.failoverToMasterEnabled() {
+		return onMaster()
+	}
+	return lastErr
+}
+
+// excludeTried returns the subset of indices not present in tried,
+// preserving order.
+func excludeTried(indices []int, tried map[int]bool) []int {
+	if len(tried) == 0 {
+		return indices
+	}
+	filtered := make([]int, 0, len(indices))
+	for _, idx := range indices {
+		if !tried[idx] {
+			filtered = append(filtered, idx)
+		}
+	}
+	return filtered
+}
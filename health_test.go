@@ -0,0 +1,125 @@
+package sqlxentrypoint
+
+import (
+	"context"
+	"testing"
+)
+
+// fakeNetErr simulates a connectivity failure the way a real net.Error
+// would, without being driver.ErrBadConn: the database/sql package
+// transparently retries ErrBadConn on a fresh connection itself, which would
+// mask the failure from nap's own failover logic before it ever saw it.
+type fakeNetErr struct{}
+
+func (fakeNetErr) Error() string   { return "fake: connection refused" }
+func (fakeNetErr) Timeout() bool   { return false }
+func (fakeNetErr) Temporary() bool { return true }
+
+func openFakeDBNoHealthCheck(t *testing.T, names ...string) *DB {
+	t.Helper()
+	db := openFakeDB(t, names...)
+	db.SetHealthCheckInterval(0) // avoid a background Ping racing the test
+	return db
+}
+
+func TestWithFailoverRetriesAnotherSlaveOnConnErr(t *testing.T) {
+	db := openFakeDBNoHealthCheck(t, "master", "slave0", "slave1")
+
+	fakeRegistryMu.Lock()
+	bad := fakeRegistry["slave0"]
+	good := fakeRegistry["slave1"]
+	fakeRegistryMu.Unlock()
+	bad.failNext(fakeNetErr{})
+
+	// Force the bad slave to be tried first so the retry path is exercised
+	// deterministically regardless of round-robin's starting offset.
+	db.SetBalancer(&pinnedBalancer{first: 0})
+
+	var dest row
+	if err := db.GetContext(context.Background(), &dest, "SELECT * FROM t"); err != nil {
+		t.Fatalf("GetContext() error = %v", err)
+	}
+	if dest.Source != "slave1" {
+		t.Errorf("GetContext() served by %q, want slave1 after slave0 failed", dest.Source)
+	}
+	if good.queryCount() != 1 {
+		t.Errorf("slave1.queryCount() = %d, want 1", good.queryCount())
+	}
+
+	healthy := db.Healthy()
+	if healthy[0] {
+		t.Errorf("Healthy()[0] = true, want false after a connectivity error")
+	}
+	if !healthy[1] {
+		t.Errorf("Healthy()[1] = false, want true")
+	}
+}
+
+func TestWithFailoverFallsBackToMasterWhenEnabled(t *testing.T) {
+	db := openFakeDBNoHealthCheck(t, "master", "slave0")
+	db.SetFailoverToMaster(true)
+
+	fakeRegistryMu.Lock()
+	bad := fakeRegistry["slave0"]
+	fakeRegistryMu.Unlock()
+	bad.failNext(fakeNetErr{})
+
+	var dest row
+	if err := db.GetContext(context.Background(), &dest, "SELECT * FROM t"); err != nil {
+		t.Fatalf("GetContext() error = %v", err)
+	}
+	if dest.Source != "master" {
+		t.Errorf("GetContext() served by %q, want master after every slave failed", dest.Source)
+	}
+}
+
+func TestWithFailoverReturnsLastErrorWhenMasterFallbackDisabled(t *testing.T) {
+	db := openFakeDBNoHealthCheck(t, "master", "slave0")
+
+	fakeRegistryMu.Lock()
+	bad := fakeRegistry["slave0"]
+	fakeRegistryMu.Unlock()
+	bad.failNext(fakeNetErr{})
+
+	var dest row
+	err := db.GetContext(context.Background(), &dest, "SELECT * FROM t")
+	if _, ok := err.(fakeNetErr); !ok {
+		t.Errorf("GetContext() error = %v, want fakeNetErr", err)
+	}
+}
+
+func TestCheckSlaveHealthUpdatesHealthy(t *testing.T) {
+	db := openFakeDBNoHealthCheck(t, "master", "slave0")
+
+	fakeRegistryMu.Lock()
+	slave := fakeRegistry["slave0"]
+	fakeRegistryMu.Unlock()
+	slave.setPingErr(fakeNetErr{})
+
+	db.checkSlaveHealth()
+	if db.Healthy()[0] {
+		t.Errorf("Healthy()[0] = true after a failed ping, want false")
+	}
+
+	slave.setPingErr(nil)
+	db.checkSlaveHealth()
+	if !db.Healthy()[0] {
+		t.Errorf("Healthy()[0] = false after a successful ping, want true")
+	}
+}
+
+// pinnedBalancer always picks a fixed candidate (falling back to the first
+// candidate if that one isn't offered), letting failover tests control which
+// slave is tried first without depending on RoundRobin's internal state.
+type pinnedBalancer struct {
+	first int
+}
+
+func (p *pinnedBalancer) Pick(candidates []int) int {
+	for _, c := range candidates {
+		if c == p.first {
+			return c
+		}
+	}
+	return candidates[0]
+}
@@ -0,0 +1,20 @@
+package sqlxentrypoint
+
+// scatter concurrently invokes fn for i in [0,n) and returns the first
+// non-nil error encountered, if any, after all goroutines have finished.
+func scatter(n int, fn func(i int) error) error {
+	errs := make(chan error, n)
+
+	for i := 0; i < n; i++ {
+		go func(i int) { errs <- fn(i) }(i)
+	}
+
+	var err error
+	for i := 0; i < n; i++ {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,152 @@
+package sqlxentrypoint
+
+import (
+	"errors"
+	"hash/crc32"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrNoShards is returned by OpenSharded when given an empty shard list.
+var ErrNoShards = errors.New("sqlxentrypoint: OpenSharded requires at least one shard")
+
+// defaultVirtualNodes is how many ring positions each shard gets in the
+// default consistent-hash ShardRouter, trading ring size for smoother
+// rebalancing when shards are added or removed.
+const defaultVirtualNodes = 100
+
+// ShardConfig describes one shard: its master/slave DSNs, using the same
+// ";"-separated, optionally "|w="-weighted syntax as Open's
+// dataSourceNames, plus any per-shard Options.
+type ShardConfig struct {
+	DataSourceNames string
+	Options         Options
+}
+
+// ShardRouter maps a routing key to a shard index in [0, n), where n is the
+// number of shards passed to OpenSharded.
+type ShardRouter interface {
+	Route(key string) int
+}
+
+// ShardedDB generalizes DB from a single master/slaves group to S shards,
+// each its own master/slaves group, selected by a user-supplied routing
+// key. Callers are expected to pick a key that groups related rows onto
+// the same shard (e.g. a tenant or account id).
+type ShardedDB struct {
+	shards []*DB
+	router ShardRouter
+}
+
+// OpenSharded concurrently opens every shard's physical databases. If
+// router is nil, a ConsistentHashRouter with defaultVirtualNodes per shard
+// is used. shards must be non-empty, or ErrNoShards is returned.
+func OpenSharded(driverName string, shards []ShardConfig, router ShardRouter) (*ShardedDB, error) {
+	if len(shards) == 0 {
+		return nil, ErrNoShards
+	}
+
+	if router == nil {
+		router = NewConsistentHashRouter(len(shards), defaultVirtualNodes)
+	}
+
+	sdb := &ShardedDB{
+		shards: make([]*DB, len(shards)),
+		router: router,
+	}
+
+	err := scatter(len(shards), func(i int) (err error) {
+		sdb.shards[i], err = Open(driverName, shards[i].DataSourceNames, shards[i].Options)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sdb, nil
+}
+
+// shardFor returns the shard DB that key routes to.
+func (s *ShardedDB) shardFor(key string) *DB {
+	return s.shards[s.router.Route(key)]
+}
+
+// Master returns the master physical database of the shard that key
+// routes to.
+func (s *ShardedDB) Master(key string) *sqlx.DB {
+	return s.shardFor(key).Master()
+}
+
+// Slave returns a slave physical database of the shard that key routes to.
+func (s *ShardedDB) Slave(key string) *sqlx.DB {
+	return s.shardFor(key).Slave()
+}
+
+// ForEachShard concurrently runs fn against every shard's DB, for fan-out
+// queries or migrations; the caller is responsible for merging results
+// across shards. It returns the first non-nil error, if any, after every
+// shard has been visited.
+func (s *ShardedDB) ForEachShard(fn func(shard int, db *DB) error) error {
+	return scatter(len(s.shards), func(i int) error {
+		return fn(i, s.shards[i])
+	})
+}
+
+// Close closes every shard concurrently, releasing any open resources.
+func (s *ShardedDB) Close() error {
+	return scatter(len(s.shards), func(i int) error {
+		return s.shards[i].Close()
+	})
+}
+
+// ConsistentHashRouter is the default ShardRouter: it places each shard at
+// several points ("virtual nodes") around a hash ring and routes a key to
+// the shard owning the next point clockwise from the key's hash. This
+// keeps most keys on the same shard when the shard count changes, unlike a
+// plain hash % n router.
+type ConsistentHashRouter struct {
+	ring    []uint32
+	shardOf map[uint32]int
+}
+
+// NewConsistentHashRouter builds a ConsistentHashRouter for n shards, each
+// given virtualNodes points on the ring.
+func NewConsistentHashRouter(n, virtualNodes int) *ConsistentHashRouter {
+	r := &ConsistentHashRouter{
+		shardOf: make(map[uint32]int, n*virtualNodes),
+	}
+	for shard := 0; shard < n; shard++ {
+		for v := 0; v < virtualNodes; v++ {
+			h := ringHash(shard, v)
+			r.ring = append(r.ring, h)
+			r.shardOf[h] = shard
+		}
+	}
+	sort.Slice(r.ring, func(i, j int) bool { return r.ring[i] < r.ring[j] })
+	return r
+}
+
+// Route hashes key and returns the shard owning the next ring position at
+// or after it, wrapping around to the first position.
+func (r *ConsistentHashRouter) Route(key string) int {
+	if len(r.ring) == 0 {
+		return 0
+	}
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(r.ring), func(i int) bool { return r.ring[i] >= h })
+	if i == len(r.ring) {
+		i = 0
+	}
+	return r.shardOf[r.ring[i]]
+}
+
+// ringHash derives a virtual node's ring position from its shard and
+// virtual-node index.
+func ringHash(shard, virtualNode int) uint32 {
+	b := []byte{
+		byte(shard >> 24), byte(shard >> 16), byte(shard >> 8), byte(shard),
+		byte(virtualNode >> 24), byte(virtualNode >> 16), byte(virtualNode >> 8), byte(virtualNode),
+	}
+	return crc32.ChecksumIEEE(b)
+}
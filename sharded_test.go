@@ -0,0 +1,148 @@
+package sqlxentrypoint
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestConsistentHashRouterIsStable(t *testing.T) {
+	r := NewConsistentHashRouter(4, defaultVirtualNodes)
+	keys := []string{"tenant-1", "tenant-2", "tenant-3", "account-42"}
+	for _, k := range keys {
+		first := r.Route(k)
+		for i := 0; i < 10; i++ {
+			if got := r.Route(k); got != first {
+				t.Errorf("Route(%q) = %d on call %d, want stable %d", k, got, i, first)
+			}
+		}
+	}
+}
+
+func TestConsistentHashRouterDistributesKeys(t *testing.T) {
+	const shards = 4
+	r := NewConsistentHashRouter(shards, defaultVirtualNodes)
+	counts := make([]int, shards)
+	for i := 0; i < 10000; i++ {
+		counts[r.Route(fmt.Sprintf("key-%d", i))]++
+	}
+	for shard, c := range counts {
+		if c < 1500 || c > 3500 {
+			t.Errorf("shard %d got %d of 10000 keys, want roughly 2500", shard, c)
+		}
+	}
+}
+
+// TestConsistentHashRouterMostlyStableAcrossResize is the point of using a
+// hash ring instead of key % n: adding a shard should only reassign keys
+// that land on the new shard's virtual nodes, not rehash everything.
+func TestConsistentHashRouterMostlyStableAcrossResize(t *testing.T) {
+	before := NewConsistentHashRouter(4, defaultVirtualNodes)
+	after := NewConsistentHashRouter(5, defaultVirtualNodes)
+
+	const n = 10000
+	moved := 0
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		if before.Route(key) != after.Route(key) {
+			moved++
+		}
+	}
+
+	// Expect roughly 1/5 of keys to move to the new shard, not the ~4/5 a
+	// naive key % n router would reshuffle.
+	if moved > n/2 {
+		t.Errorf("adding a shard moved %d/%d keys, want well under half", moved, n)
+	}
+}
+
+func TestConsistentHashRouterEmptyRingRoutesToZero(t *testing.T) {
+	r := NewConsistentHashRouter(0, defaultVirtualNodes)
+	if got := r.Route("anything"); got != 0 {
+		t.Errorf("Route() on empty ring = %d, want 0", got)
+	}
+}
+
+// fixedRouter routes key to whatever shard index it's mapped to, letting
+// shard-level tests pick a key's shard deterministically rather than
+// depending on ConsistentHashRouter's hash.
+type fixedRouter map[string]int
+
+func (r fixedRouter) Route(key string) int { return r[key] }
+
+func openFakeShardedDB(t *testing.T, router ShardRouter, shardDSNs ...string) *ShardedDB {
+	t.Helper()
+	shards := make([]ShardConfig, len(shardDSNs))
+	for i, dsn := range shardDSNs {
+		shards[i] = ShardConfig{DataSourceNames: dsn}
+	}
+	sdb, err := OpenSharded("napfake", shards, router)
+	if err != nil {
+		t.Fatalf("OpenSharded() error = %v", err)
+	}
+	t.Cleanup(func() { sdb.Close() })
+	return sdb
+}
+
+func TestShardedDBRoutesMasterAndSlaveByKey(t *testing.T) {
+	newFakeDB(t, "shard0-master")
+	newFakeDB(t, "shard0-slave0")
+	newFakeDB(t, "shard1-master")
+	newFakeDB(t, "shard1-slave0")
+
+	sdb := openFakeShardedDB(t, fixedRouter{"a": 0, "b": 1},
+		"shard0-master;shard0-slave0", "shard1-master;shard1-slave0")
+
+	var dest row
+	if err := sdb.Master("a").Get(&dest, "SELECT * FROM t"); err != nil {
+		t.Fatalf("Master(\"a\").Get() error = %v", err)
+	}
+	if dest.Source != "shard0-master" {
+		t.Errorf("Master(\"a\") served by %q, want shard0-master", dest.Source)
+	}
+
+	if err := sdb.Slave("a").Get(&dest, "SELECT * FROM t"); err != nil {
+		t.Fatalf("Slave(\"a\").Get() error = %v", err)
+	}
+	if dest.Source != "shard0-slave0" {
+		t.Errorf("Slave(\"a\") served by %q, want shard0-slave0", dest.Source)
+	}
+
+	if err := sdb.Master("b").Get(&dest, "SELECT * FROM t"); err != nil {
+		t.Fatalf("Master(\"b\").Get() error = %v", err)
+	}
+	if dest.Source != "shard1-master" {
+		t.Errorf("Master(\"b\") served by %q, want shard1-master", dest.Source)
+	}
+}
+
+func TestShardedDBForEachShardVisitsEveryShard(t *testing.T) {
+	newFakeDB(t, "shard0-master")
+	newFakeDB(t, "shard1-master")
+
+	sdb := openFakeShardedDB(t, fixedRouter{}, "shard0-master", "shard1-master")
+
+	var mu sync.Mutex
+	visited := make(map[int]bool)
+	err := sdb.ForEachShard(func(shard int, db *DB) error {
+		mu.Lock()
+		visited[shard] = true
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachShard() error = %v", err)
+	}
+	if len(visited) != 2 || !visited[0] || !visited[1] {
+		t.Errorf("ForEachShard() visited %v, want {0,1}", visited)
+	}
+}
+
+func TestOpenShardedRejectsEmptyShardList(t *testing.T) {
+	if _, err := OpenSharded("napfake", nil, nil); err != ErrNoShards {
+		t.Errorf("OpenSharded(nil shards) error = %v, want ErrNoShards", err)
+	}
+	if _, err := OpenSharded("napfake", []ShardConfig{}, nil); err != ErrNoShards {
+		t.Errorf("OpenSharded(empty shards) error = %v, want ErrNoShards", err)
+	}
+}
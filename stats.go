@@ -0,0 +1,110 @@
+package sqlxentrypoint
+
+import (
+	"database/sql"
+	"sync/atomic"
+	"time"
+)
+
+// dispatchStats holds nap-specific per-slave counters, aligned with
+// db.pdbs[1:] the same way healthState is.
+type dispatchStats struct {
+	dispatched []int64 // queries routed to this slave
+	skipped    []int64 // times this slave was passed over for being unhealthy
+}
+
+func newDispatchStats(n int) *dispatchStats {
+	return &dispatchStats{
+		dispatched: make([]int64, n),
+		skipped:    make([]int64, n),
+	}
+}
+
+func (s *dispatchStats) incDispatched(i int) {
+	if i >= 0 && i < len(s.dispatched) {
+		atomic.AddInt64(&s.dispatched[i], 1)
+	}
+}
+
+func (s *dispatchStats) incSkipped(i int) {
+	if i >= 0 && i < len(s.skipped) {
+		atomic.AddInt64(&s.skipped[i], 1)
+	}
+}
+
+func (s *dispatchStats) snapshot() (dispatched, skipped []int64) {
+	dispatched = make([]int64, len(s.dispatched))
+	skipped = make([]int64, len(s.skipped))
+	for i := range dispatched {
+		dispatched[i] = atomic.LoadInt64(&s.dispatched[i])
+	}
+	for i := range skipped {
+		skipped[i] = atomic.LoadInt64(&s.skipped[i])
+	}
+	return dispatched, skipped
+}
+
+// DBStats aggregates connection-pool statistics across every physical
+// database behind a DB, alongside nap-specific routing counters.
+type DBStats struct {
+	// Master and Slaves are the raw stats for each physical db, in the
+	// order Slaves were passed to Open.
+	Master sql.DBStats
+	Slaves []sql.DBStats
+
+	// The fields below are Master plus every entry in Slaves summed
+	// together, for callers that just want one set of pool numbers.
+	OpenConnections   int
+	InUse             int
+	Idle              int
+	WaitCount         int64
+	WaitDuration      time.Duration
+	MaxIdleClosed     int64
+	MaxIdleTimeClosed int64
+	MaxLifetimeClosed int64
+
+	// QueriesDispatched and SkippedUnhealthy are nap-specific: for each
+	// slave, how many queries it has served and how many times it was
+	// passed over in favor of another slave because it was unhealthy.
+	// Both are aligned with Slaves.
+	QueriesDispatched []int64
+	SkippedUnhealthy  []int64
+}
+
+// add folds s into the running totals.
+func (d *DBStats) add(s sql.DBStats) {
+	d.OpenConnections += s.OpenConnections
+	d.InUse += s.InUse
+	d.Idle += s.Idle
+	d.WaitCount += s.WaitCount
+	d.WaitDuration += s.WaitDuration
+	d.MaxIdleClosed += s.MaxIdleClosed
+	d.MaxIdleTimeClosed += s.MaxIdleTimeClosed
+	d.MaxLifetimeClosed += s.MaxLifetimeClosed
+}
+
+// Stats aggregates sql.DBStats from every underlying physical database,
+// alongside a master/slave breakdown and nap's own routing counters.
+func (db *DB) Stats() DBStats {
+	var out DBStats
+	out.Slaves = make([]sql.DBStats, len(db.pdbs)-1)
+
+	_ = scatter(len(db.pdbs), func(i int) error {
+		s := db.pdbs[i].Stats()
+		if i == 0 {
+			out.Master = s
+		} else {
+			out.Slaves[i-1] = s
+		}
+		return nil
+	})
+
+	out.add(out.Master)
+	for _, s := range out.Slaves {
+		out.add(s)
+	}
+
+	out.QueriesDispatched, out.SkippedUnhealthy = db.stats.snapshot()
+
+	return out
+}
@@ -0,0 +1,65 @@
+package sqlxentrypoint
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestDBStatsAddAggregates(t *testing.T) {
+	var d DBStats
+	d.add(sql.DBStats{
+		OpenConnections: 2, InUse: 1, Idle: 1,
+		WaitCount: 3, WaitDuration: time.Second,
+		MaxIdleClosed: 1, MaxIdleTimeClosed: 2, MaxLifetimeClosed: 3,
+	})
+	d.add(sql.DBStats{
+		OpenConnections: 5, InUse: 2, Idle: 3,
+		WaitCount: 1, WaitDuration: 2 * time.Second,
+		MaxIdleClosed: 4, MaxIdleTimeClosed: 5, MaxLifetimeClosed: 6,
+	})
+
+	want := DBStats{
+		OpenConnections: 7, InUse: 3, Idle: 4,
+		WaitCount: 4, WaitDuration: 3 * time.Second,
+		MaxIdleClosed: 5, MaxIdleTimeClosed: 7, MaxLifetimeClosed: 9,
+	}
+	if d.OpenConnections != want.OpenConnections || d.InUse != want.InUse || d.Idle != want.Idle ||
+		d.WaitCount != want.WaitCount || d.WaitDuration != want.WaitDuration ||
+		d.MaxIdleClosed != want.MaxIdleClosed || d.MaxIdleTimeClosed != want.MaxIdleTimeClosed ||
+		d.MaxLifetimeClosed != want.MaxLifetimeClosed {
+		t.Errorf("add() accumulated %+v, want %+v", d, want)
+	}
+}
+
+func TestDispatchStatsSnapshot(t *testing.T) {
+	s := newDispatchStats(3)
+	s.incDispatched(0)
+	s.incDispatched(0)
+	s.incDispatched(1)
+	s.incSkipped(2)
+
+	dispatched, skipped := s.snapshot()
+	if got, want := dispatched, []int64{2, 1, 0}; !equalInt64(got, want) {
+		t.Errorf("dispatched = %v, want %v", got, want)
+	}
+	if got, want := skipped, []int64{0, 0, 1}; !equalInt64(got, want) {
+		t.Errorf("skipped = %v, want %v", got, want)
+	}
+
+	// Out-of-range indices must be ignored rather than panicking.
+	s.incDispatched(-1)
+	s.incSkipped(3)
+}
+
+func equalInt64(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,339 @@
+package sqlxentrypoint
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Stmt is a prepared statement spanning every physical database behind a
+// DB. Its Exec/Get/Select methods dispatch to the master or a healthy slave
+// using the same write/read rules and automatic health-check failover as
+// DB's Context methods (see DB.withFailoverIndex), but run against an
+// already-prepared statement instead of re-preparing query on every call.
+type Stmt struct {
+	db    *DB
+	query string
+
+	mu     sync.RWMutex
+	epoch  uint64
+	master *sqlx.Stmt
+	slaves []*sqlx.Stmt // aligned with db.pdbs[1:]
+}
+
+// Preparex prepares query on every physical database concurrently and
+// returns a Stmt that dispatches Exec/Get/Select across them.
+func (db *DB) Preparex(query string) (*Stmt, error) {
+	s := &Stmt{db: db, query: query}
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// refresh (re)prepares query on every physical database and swaps it in,
+// closing whatever was previously prepared. It's called once by Preparex
+// and again by ensureFresh whenever the DB's topology has moved on since
+// the last prepare.
+func (s *Stmt) refresh() error {
+	db := s.db
+	epoch := db.topologyEpoch()
+
+	master := make([]*sqlx.Stmt, 1)
+	slaves := make([]*sqlx.Stmt, len(db.pdbs)-1)
+	err := scatter(len(db.pdbs), func(i int) (err error) {
+		stmt, err := db.pdbs[i].Preparex(s.query)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			master[0] = stmt
+		} else {
+			slaves[i-1] = stmt
+		}
+		return nil
+	})
+	if err != nil {
+		// Some physical dbs may have prepared successfully before another
+		// failed; don't leak their server-side statement handles.
+		closeStmts(master[0], slaves)
+		return err
+	}
+
+	s.mu.Lock()
+	oldMaster, oldSlaves := s.master, s.slaves
+	s.epoch, s.master, s.slaves = epoch, master[0], slaves
+	s.mu.Unlock()
+
+	closeStmts(oldMaster, oldSlaves)
+	return nil
+}
+
+// ensureFresh re-prepares the statement if the DB's topology has changed
+// since it was last prepared, e.g. a slave having been added or removed.
+func (s *Stmt) ensureFresh() error {
+	s.mu.RLock()
+	stale := s.epoch != s.db.topologyEpoch()
+	s.mu.RUnlock()
+	if !stale {
+		return nil
+	}
+	return s.refresh()
+}
+
+// masterStmt and slaveStmt give withFailoverIndex access to the currently
+// prepared statements without exposing s.mu to it.
+func (s *Stmt) masterStmt() *sqlx.Stmt {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.master
+}
+
+func (s *Stmt) slaveStmt(idx int) *sqlx.Stmt {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if idx < 0 || idx >= len(s.slaves) {
+		return nil
+	}
+	return s.slaves[idx]
+}
+
+// dispatch runs fn against the master statement for writes, or otherwise
+// against a healthy slave statement, with the same automatic retry and
+// failover as DB's own Context methods.
+func (s *Stmt) dispatch(fn func(stmt *sqlx.Stmt) error) error {
+	if writeQuery.MatchString(s.query) {
+		return fn(s.masterStmt())
+	}
+	return s.db.withFailoverIndex(
+		func(idx int) error {
+			if stmt := s.slaveStmt(idx); stmt != nil {
+				return fn(stmt)
+			}
+			return fn(s.masterStmt())
+		},
+		func() error { return fn(s.masterStmt()) },
+	)
+}
+
+// Exec executes the prepared statement with the given args, always on the
+// master, the same way DB.ExecContext does.
+func (s *Stmt) Exec(args ...interface{}) (sql.Result, error) {
+	if err := s.ensureFresh(); err != nil {
+		return nil, err
+	}
+	return s.masterStmt().Exec(args...)
+}
+
+// Get executes the prepared statement and scans the single resulting row
+// into dest.
+func (s *Stmt) Get(dest interface{}, args ...interface{}) error {
+	if err := s.ensureFresh(); err != nil {
+		return err
+	}
+	return s.dispatch(func(stmt *sqlx.Stmt) error {
+		return stmt.Get(dest, args...)
+	})
+}
+
+// Select executes the prepared statement and scans the resulting rows into
+// dest.
+func (s *Stmt) Select(dest interface{}, args ...interface{}) error {
+	if err := s.ensureFresh(); err != nil {
+		return err
+	}
+	return s.dispatch(func(stmt *sqlx.Stmt) error {
+		return stmt.Select(dest, args...)
+	})
+}
+
+// Close closes the statement on every physical database.
+func (s *Stmt) Close() error {
+	s.mu.Lock()
+	master, slaves := s.master, s.slaves
+	s.master, s.slaves = nil, nil
+	s.mu.Unlock()
+	return closeStmts(master, slaves)
+}
+
+// NamedStmt is PrepareNamed's equivalent of Stmt, spanning every physical
+// database behind a DB with a query that uses sqlx's named-parameter
+// syntax instead of positional args. Its Exec/Get/Select methods dispatch
+// using the same write/read rules and automatic health-check failover as
+// DB's Context methods (see DB.withFailoverIndex).
+type NamedStmt struct {
+	db    *DB
+	query string
+
+	mu     sync.RWMutex
+	epoch  uint64
+	master *sqlx.NamedStmt
+	slaves []*sqlx.NamedStmt // aligned with db.pdbs[1:]
+}
+
+// PrepareNamed prepares query, which must use sqlx's named-parameter
+// syntax, on every physical database concurrently and returns a NamedStmt
+// that dispatches Exec/Get/Select across them.
+func (db *DB) PrepareNamed(query string) (*NamedStmt, error) {
+	s := &NamedStmt{db: db, query: query}
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *NamedStmt) refresh() error {
+	db := s.db
+	epoch := db.topologyEpoch()
+
+	master := make([]*sqlx.NamedStmt, 1)
+	slaves := make([]*sqlx.NamedStmt, len(db.pdbs)-1)
+	err := scatter(len(db.pdbs), func(i int) (err error) {
+		stmt, err := db.pdbs[i].PrepareNamed(s.query)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			master[0] = stmt
+		} else {
+			slaves[i-1] = stmt
+		}
+		return nil
+	})
+	if err != nil {
+		// Some physical dbs may have prepared successfully before another
+		// failed; don't leak their server-side statement handles.
+		closeNamedStmts(master[0], slaves)
+		return err
+	}
+
+	s.mu.Lock()
+	oldMaster, oldSlaves := s.master, s.slaves
+	s.epoch, s.master, s.slaves = epoch, master[0], slaves
+	s.mu.Unlock()
+
+	closeNamedStmts(oldMaster, oldSlaves)
+	return nil
+}
+
+func (s *NamedStmt) ensureFresh() error {
+	s.mu.RLock()
+	stale := s.epoch != s.db.topologyEpoch()
+	s.mu.RUnlock()
+	if !stale {
+		return nil
+	}
+	return s.refresh()
+}
+
+// masterStmt and slaveStmt give withFailoverIndex access to the currently
+// prepared statements without exposing s.mu to it.
+func (s *NamedStmt) masterStmt() *sqlx.NamedStmt {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.master
+}
+
+func (s *NamedStmt) slaveStmt(idx int) *sqlx.NamedStmt {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if idx < 0 || idx >= len(s.slaves) {
+		return nil
+	}
+	return s.slaves[idx]
+}
+
+// dispatch runs fn against the master statement for writes, or otherwise
+// against a healthy slave statement, with the same automatic retry and
+// failover as DB's own Context methods.
+func (s *NamedStmt) dispatch(fn func(stmt *sqlx.NamedStmt) error) error {
+	if writeQuery.MatchString(s.query) {
+		return fn(s.masterStmt())
+	}
+	return s.db.withFailoverIndex(
+		func(idx int) error {
+			if stmt := s.slaveStmt(idx); stmt != nil {
+				return fn(stmt)
+			}
+			return fn(s.masterStmt())
+		},
+		func() error { return fn(s.masterStmt()) },
+	)
+}
+
+// Exec executes the prepared statement, binding named parameters from arg,
+// always on the master, the same way DB.NamedExecContext does.
+func (s *NamedStmt) Exec(arg interface{}) (sql.Result, error) {
+	if err := s.ensureFresh(); err != nil {
+		return nil, err
+	}
+	return s.masterStmt().Exec(arg)
+}
+
+// Get executes the prepared statement, binding named parameters from arg,
+// and scans the single resulting row into dest.
+func (s *NamedStmt) Get(dest interface{}, arg interface{}) error {
+	if err := s.ensureFresh(); err != nil {
+		return err
+	}
+	return s.dispatch(func(stmt *sqlx.NamedStmt) error {
+		return stmt.Get(dest, arg)
+	})
+}
+
+// Select executes the prepared statement, binding named parameters from
+// arg, and scans the resulting rows into dest.
+func (s *NamedStmt) Select(dest interface{}, arg interface{}) error {
+	if err := s.ensureFresh(); err != nil {
+		return err
+	}
+	return s.dispatch(func(stmt *sqlx.NamedStmt) error {
+		return stmt.Select(dest, arg)
+	})
+}
+
+// Close closes the statement on every physical database.
+func (s *NamedStmt) Close() error {
+	s.mu.Lock()
+	master, slaves := s.master, s.slaves
+	s.master, s.slaves = nil, nil
+	s.mu.Unlock()
+	return closeNamedStmts(master, slaves)
+}
+
+func closeStmts(master *sqlx.Stmt, slaves []*sqlx.Stmt) error {
+	var err error
+	if master != nil {
+		if e := master.Close(); e != nil {
+			err = e
+		}
+	}
+	for _, st := range slaves {
+		if st == nil {
+			continue
+		}
+		if e := st.Close(); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func closeNamedStmts(master *sqlx.NamedStmt, slaves []*sqlx.NamedStmt) error {
+	var err error
+	if master != nil {
+		if e := master.Close(); e != nil {
+			err = e
+		}
+	}
+	for _, st := range slaves {
+		if st == nil {
+			continue
+		}
+		if e := st.Close(); e != nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,140 @@
+package sqlxentrypoint
+
+import "testing"
+
+func TestStmtSelectGoesToSlave(t *testing.T) {
+	db := openFakeDBNoHealthCheck(t, "master", "slave0")
+
+	stmt, err := db.Preparex("SELECT * FROM t")
+	if err != nil {
+		t.Fatalf("Preparex() error = %v", err)
+	}
+	defer stmt.Close()
+
+	var dest row
+	if err := stmt.Get(&dest, 1); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if dest.Source != "slave0" {
+		t.Errorf("Get() served by %q, want slave0", dest.Source)
+	}
+}
+
+func TestStmtFailsOverToAnotherSlave(t *testing.T) {
+	db := openFakeDBNoHealthCheck(t, "master", "slave0", "slave1")
+	db.SetBalancer(&pinnedBalancer{first: 0})
+
+	stmt, err := db.Preparex("SELECT * FROM t")
+	if err != nil {
+		t.Fatalf("Preparex() error = %v", err)
+	}
+	defer stmt.Close()
+
+	fakeRegistryMu.Lock()
+	bad := fakeRegistry["slave0"]
+	fakeRegistryMu.Unlock()
+	bad.failNext(fakeNetErr{})
+
+	var dest row
+	if err := stmt.Get(&dest, 1); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if dest.Source != "slave1" {
+		t.Errorf("Get() served by %q, want slave1 after slave0 failed", dest.Source)
+	}
+	if healthy := db.Healthy(); healthy[0] {
+		t.Errorf("Healthy()[0] = true, want false after a connectivity error")
+	}
+}
+
+func TestStmtExecAlwaysGoesToMaster(t *testing.T) {
+	db := openFakeDBNoHealthCheck(t, "master", "slave0")
+
+	stmt, err := db.Preparex("insert into t values (?)")
+	if err != nil {
+		t.Fatalf("Preparex() error = %v", err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(1); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+
+	fakeRegistryMu.Lock()
+	master := fakeRegistry["master"]
+	slave := fakeRegistry["slave0"]
+	fakeRegistryMu.Unlock()
+	if master.execCount() != 1 {
+		t.Errorf("master.execCount() = %d, want 1", master.execCount())
+	}
+	if slave.execCount() != 0 {
+		t.Errorf("slave0.execCount() = %d, want 0", slave.execCount())
+	}
+}
+
+func TestNamedStmtSelectGoesToSlave(t *testing.T) {
+	db := openFakeDBNoHealthCheck(t, "master", "slave0")
+
+	stmt, err := db.PrepareNamed("SELECT * FROM t WHERE id = :id")
+	if err != nil {
+		t.Fatalf("PrepareNamed() error = %v", err)
+	}
+	defer stmt.Close()
+
+	var dest row
+	if err := stmt.Get(&dest, row{ID: 1}); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if dest.Source != "slave0" {
+		t.Errorf("Get() served by %q, want slave0", dest.Source)
+	}
+}
+
+func TestNamedStmtFailsOverToAnotherSlave(t *testing.T) {
+	db := openFakeDBNoHealthCheck(t, "master", "slave0", "slave1")
+	db.SetBalancer(&pinnedBalancer{first: 0})
+
+	stmt, err := db.PrepareNamed("SELECT * FROM t WHERE id = :id")
+	if err != nil {
+		t.Fatalf("PrepareNamed() error = %v", err)
+	}
+	defer stmt.Close()
+
+	fakeRegistryMu.Lock()
+	bad := fakeRegistry["slave0"]
+	fakeRegistryMu.Unlock()
+	bad.failNext(fakeNetErr{})
+
+	var dest row
+	if err := stmt.Get(&dest, row{ID: 1}); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if dest.Source != "slave1" {
+		t.Errorf("Get() served by %q, want slave1 after slave0 failed", dest.Source)
+	}
+}
+
+func TestNamedStmtExecAlwaysGoesToMaster(t *testing.T) {
+	db := openFakeDBNoHealthCheck(t, "master", "slave0")
+
+	stmt, err := db.PrepareNamed("insert into t values (:id)")
+	if err != nil {
+		t.Fatalf("PrepareNamed() error = %v", err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(row{ID: 1}); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+
+	fakeRegistryMu.Lock()
+	master := fakeRegistry["master"]
+	slave := fakeRegistry["slave0"]
+	fakeRegistryMu.Unlock()
+	if master.execCount() != 1 {
+		t.Errorf("master.execCount() = %d, want 1", master.execCount())
+	}
+	if slave.execCount() != 0 {
+		t.Errorf("slave0.execCount() = %d, want 0", slave.execCount())
+	}
+}